@@ -0,0 +1,176 @@
+package disvm
+
+import "fmt"
+
+// CompiledRegCount bounds the fixed-size register file used by the
+// compiled path. Prime-indexed register addresses are reduced modulo
+// CompiledRegCount, trading the interpreter's unbounded map[uint64]uint64
+// for a flat array with no allocation pressure in hot loops.
+const CompiledRegCount = 256
+
+// compiledRegs is the fixed-size register file a CompiledProgram reads
+// and writes instead of DisVM.Regs.
+type compiledRegs [CompiledRegCount]uint64
+
+func regIndex(addr uint32) uint64 {
+	return uint64(addr) % CompiledRegCount
+}
+
+// compiledStep is one closure-compiled instruction. next is the PC to
+// continue at; steps that don't branch return pc+1 themselves.
+type compiledStep func(vm *DisVM, regs *compiledRegs, pc uint32) (next uint32, err error)
+
+// CompiledProgram is a DisVM instruction stream translated ahead of time
+// into a chain of Go closures, so tight loops of OpMulPrime/OpExpPrime/
+// OpBranch avoid the interpreter's per-instruction switch dispatch and
+// Regs map lookups. Opcodes without a closure-compiled implementation
+// fall back to interpreting that single instruction through DisVM.Execute
+// at run time, so compiled and uncompiled opcodes can be mixed freely.
+type CompiledProgram struct {
+	code  []Instruction
+	steps []compiledStep
+}
+
+// Compile translates code into a CompiledProgram. Compile itself never
+// fails closed over an unsupported opcode; unsupported opcodes get an
+// interpreter fallback step instead. The returned error is always nil:
+// jump and branch targets are not range-checked, and a target at or past
+// the end of code ends Run.
+func Compile(code []Instruction) (*CompiledProgram, error) {
+	p := &CompiledProgram{code: code, steps: make([]compiledStep, len(code))}
+	for i, instr := range code {
+		step, err := compileStep(instr)
+		if err != nil {
+			return nil, fmt.Errorf("disvm: compile instruction %d: %w", i, err)
+		}
+		p.steps[i] = step
+	}
+	return p, nil
+}
+
+func compileStep(instr Instruction) (compiledStep, error) {
+	switch instr.Op {
+	case OpMulPrime:
+		dst, src1, prime := instr.Dst, instr.Src1, instr.Prime
+		traceInstr := instr
+		return func(vm *DisVM, regs *compiledRegs, pc uint32) (uint32, error) {
+			if err := chargeCompiledStep(vm, pc, traceInstr, regs); err != nil {
+				return pc, err
+			}
+			regs[regIndex(dst)] = regs[regIndex(src1)] * prime
+			return pc + 1, nil
+		}, nil
+
+	case OpExpPrime:
+		dst, prime, exp := instr.Dst, instr.Prime, instr.Exp
+		traceInstr := instr
+		return func(vm *DisVM, regs *compiledRegs, pc uint32) (uint32, error) {
+			if err := chargeCompiledStep(vm, pc, traceInstr, regs); err != nil {
+				return pc, err
+			}
+			result := uint64(1)
+			for i := uint32(0); i < exp; i++ {
+				result *= prime
+			}
+			regs[regIndex(dst)] = result
+			return pc + 1, nil
+		}, nil
+
+	case OpBranch:
+		src1, target := instr.Src1, instr.Dst
+		traceInstr := instr
+		return func(vm *DisVM, regs *compiledRegs, pc uint32) (uint32, error) {
+			if err := chargeCompiledStep(vm, pc, traceInstr, regs); err != nil {
+				return pc, err
+			}
+			if regs[regIndex(src1)] != 0 {
+				return target, nil
+			}
+			return pc + 1, nil
+		}, nil
+
+	case OpJump:
+		target := instr.Dst
+		traceInstr := instr
+		return func(vm *DisVM, regs *compiledRegs, pc uint32) (uint32, error) {
+			if err := chargeCompiledStep(vm, pc, traceInstr, regs); err != nil {
+				return pc, err
+			}
+			return target, nil
+		}, nil
+
+	default:
+		// Fall back to the interpreter for opcodes the compiled path
+		// doesn't special-case (syscalls, memory ops, factorization, ...).
+		// The interpreter only ever sees vm.Regs, so the fallback syncs
+		// the fixed-size register file into vm.Regs before calling it
+		// and back out afterward, keeping both views of a program's
+		// registers consistent as execution moves between the two.
+		fallback := instr
+		return func(vm *DisVM, regs *compiledRegs, pc uint32) (uint32, error) {
+			regsToVM(vm, regs)
+			vm.PC = pc
+			if err := vm.Execute(fallback); err != nil {
+				return pc, err
+			}
+			vmToRegs(vm, regs)
+			return vm.PC, nil
+		}, nil
+	}
+}
+
+// regsToVM copies the compiled register file into vm.Regs so an
+// interpreter fallback step sees values written by compiled steps.
+func regsToVM(vm *DisVM, regs *compiledRegs) {
+	for i, v := range regs {
+		vm.Regs[uint64(i)] = v
+	}
+}
+
+// vmToRegs copies vm.Regs back into the compiled register file so later
+// compiled steps see values written by an interpreter fallback step.
+// Addresses outside CompiledRegCount alias onto regIndex(addr); callers
+// that need the full map[uint64]uint64 address space for a mixed
+// compiled/interpreted program should keep register addresses below
+// CompiledRegCount.
+func vmToRegs(vm *DisVM, regs *compiledRegs) {
+	for addr, v := range vm.Regs {
+		regs[regIndex(uint32(addr))] = v
+	}
+}
+
+// chargeCompiledStep enforces vm's CU budget and gives its Tracer
+// visibility into a compiled step, the same as Execute does for the
+// interpreter path; without it, a compiled OpMulPrime/OpExpPrime/
+// OpBranch/OpJump loop would run unmetered and invisible to tracing.
+// Tracer.CaptureState reads vm.Regs, so regs is synced into it first
+// when a Tracer is attached; that sync is skipped otherwise to keep the
+// untraced hot path free of per-instruction map writes.
+func chargeCompiledStep(vm *DisVM, pc uint32, instr Instruction, regs *compiledRegs) error {
+	if vm.Tracer != nil {
+		regsToVM(vm, regs)
+	}
+	return vm.chargeAndTrace(pc, instr, opCost(vm, instr))
+}
+
+// Run executes the compiled program against vm, starting at vm.PC and
+// using a fixed-size register file for the compiled opcodes instead of
+// vm.Regs. vm.Regs is seeded into that register file before the first
+// instruction and written back after the last, so a caller that sets up
+// or inspects registers through vm.Regs sees a single consistent view.
+func (p *CompiledProgram) Run(vm *DisVM) error {
+	var regs compiledRegs
+	vmToRegs(vm, &regs)
+
+	for vm.PC < uint32(len(p.steps)) {
+		step := p.steps[vm.PC]
+		next, err := step(vm, &regs, vm.PC)
+		if err != nil {
+			return err
+		}
+		vm.PC = next
+	}
+
+	regsToVM(vm, &regs)
+	return nil
+}
@@ -0,0 +1,48 @@
+package disvm
+
+import "testing"
+
+// primeArithProgram builds a straight-line chain of OpMulPrime/OpExpPrime
+// instructions, representative of the hot prime-arithmetic paths Compile
+// targets: no syscalls, no memory traffic, just register-to-register
+// arithmetic dispatched n times.
+func primeArithProgram(n int) []Instruction {
+	code := make([]Instruction, 0, n)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			code = append(code, Instruction{Op: OpMulPrime, Dst: 1, Src1: 1, Prime: 3})
+		} else {
+			code = append(code, Instruction{Op: OpExpPrime, Dst: 2, Prime: 2, Exp: 5})
+		}
+	}
+	return code
+}
+
+func BenchmarkInterpretPrimeArith(b *testing.B) {
+	code := primeArithProgram(1000)
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	vm.LoadProgram(code)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm.PC = 0
+		if err := vm.Run(); err != nil {
+			b.Fatalf("run: %v", err)
+		}
+	}
+}
+
+func BenchmarkCompiledPrimeArith(b *testing.B) {
+	code := primeArithProgram(1000)
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	prog, err := Compile(code)
+	if err != nil {
+		b.Fatalf("compile: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm.PC = 0
+		if err := prog.Run(vm); err != nil {
+			b.Fatalf("run: %v", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package disvm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompiledProgramMixesFallbackOp(t *testing.T) {
+	// r1 = r0 * 7 (compiled OpMulPrime), then store r1 to Memory[2]
+	// (interpreter fallback OpStore). The store must see the value the
+	// compiled step wrote to r1.
+	code := []Instruction{
+		{Op: OpMulPrime, Dst: 1, Src1: 0, Prime: 7},
+		{Op: OpStore, Dst: 2, Src1: 1},
+	}
+
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	vm.Regs[0] = 6
+
+	prog, err := Compile(code)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if err := prog.Run(vm); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	got, ok := vm.Memory[2].(uint64)
+	if !ok || got != 42 {
+		t.Fatalf("Memory[2] = %v, want 42", vm.Memory[2])
+	}
+}
+
+func TestCompiledProgramEnforcesCUBudget(t *testing.T) {
+	// An infinite OpBranch loop: r0 starts at 1, so the branch at PC 0
+	// always jumps back to itself and the OpMulPrime at PC 1 is never
+	// reached. Run must trip ErrOutOfCompute rather than loop unmetered
+	// forever.
+	code := []Instruction{
+		{Op: OpBranch, Src1: 0, Dst: 0},
+		{Op: OpMulPrime, Dst: 0, Src1: 0, Prime: 1},
+	}
+
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 5})
+	vm.Regs[0] = 1
+
+	prog, err := Compile(code)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- prog.Run(vm) }()
+
+	select {
+	case err := <-done:
+		if err != ErrOutOfCompute {
+			t.Fatalf("Run() = %v, want ErrOutOfCompute", err)
+		}
+		if vm.CULeft != 0 {
+			t.Fatalf("CULeft = %d, want 0", vm.CULeft)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("compiled OpBranch loop ran unmetered past its CU budget")
+	}
+}
+
+func TestCompiledProgramTracesCompiledSteps(t *testing.T) {
+	code := []Instruction{
+		{Op: OpMulPrime, Dst: 1, Src1: 0, Prime: 7},
+	}
+
+	var out bytes.Buffer
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	vm.Regs[0] = 6
+	vm.SetTracer(NewJSONTracer(&out))
+
+	prog, err := Compile(code)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if err := prog.Run(vm); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if vm.Regs[1] != 42 {
+		t.Fatalf("Regs[1] = %d, want 42", vm.Regs[1])
+	}
+
+	// A compiled OpMulPrime step must reach the Tracer just like an
+	// interpreted one does; CompiledProgram.Run itself doesn't call
+	// CaptureStart/CaptureEnd, only the per-step CaptureState.
+	if !strings.Contains(out.String(), `"event":"step"`) {
+		t.Fatalf("tracer output = %q, want a step event for the compiled OpMulPrime", out.String())
+	}
+}
@@ -0,0 +1,319 @@
+// Package disasm provides a text assembler and disassembler for Dis VM
+// bytecode, modeled after the mnemonic-table style SBF/eBPF disassemblers
+// use: one line per instruction, a fixed mnemonic per opcode (and per
+// well-known syscall ID), and register/immediate operands written the
+// way a human would.
+package disasm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cogpy/echo.kern/kernel/disvm"
+)
+
+// mnemonics maps each opcode to its textual name.
+var mnemonics = map[disvm.OpCode]string{
+	disvm.OpLoad:      "load",
+	disvm.OpStore:     "store",
+	disvm.OpAlloc:     "alloc",
+	disvm.OpMulPrime:  "mulprime",
+	disvm.OpExpPrime:  "expprime",
+	disvm.OpFactorize: "factorize",
+	disvm.OpCall:      "call",
+	disvm.OpRet:       "ret",
+	disvm.OpJump:      "jump",
+	disvm.OpBranch:    "branch",
+	disvm.OpSyscall:   "syscall",
+}
+
+// syscallMnemonics maps well-known syscall IDs (an OpSyscall's Dst) to
+// the mnemonic Disassemble prints in place of the generic "syscall".
+var syscallMnemonics = map[uint32]string{
+	disvm.SyscallMembraneCreate: "membrane.create",
+	disvm.SyscallMembraneEvolve: "membrane.evolve",
+	disvm.SyscallMembraneSend:   "membrane.send",
+	disvm.SyscallNodeCreate:     "node.create",
+	disvm.SyscallEdgeCreate:     "edge.create",
+	disvm.SyscallGraphTraverse:  "graph.traverse",
+}
+
+var mnemonicToOp = func() map[string]disvm.OpCode {
+	m := make(map[string]disvm.OpCode, len(mnemonics))
+	for op, name := range mnemonics {
+		m[name] = op
+	}
+	return m
+}()
+
+var mnemonicToSyscall = func() map[string]uint32 {
+	m := make(map[string]uint32, len(syscallMnemonics))
+	for id, name := range syscallMnemonics {
+		m[name] = id
+	}
+	return m
+}()
+
+// Disassemble renders code as one mnemonic line per instruction, e.g.
+//
+//	mulprime r3, r1, #7
+//	expprime r2, #3, #5
+//	membrane.create #11
+func Disassemble(code []disvm.Instruction) string {
+	var b strings.Builder
+	for _, instr := range code {
+		b.WriteString(disassembleOne(instr))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func disassembleOne(instr disvm.Instruction) string {
+	if instr.Op == disvm.OpSyscall {
+		name, ok := syscallMnemonics[instr.Dst]
+		if !ok {
+			return fmt.Sprintf("syscall #%d", instr.Dst)
+		}
+		switch instr.Dst {
+		case disvm.SyscallMembraneCreate:
+			// instr.Prime names the new membrane's ID (see
+			// scheduler.registerMembraneSyscalls).
+			return fmt.Sprintf("%s #%d", name, instr.Prime)
+		case disvm.SyscallMembraneEvolve:
+			// instr.Src1 names the register to receive the next inbound
+			// message's payload; Src2 is unused.
+			return fmt.Sprintf("%s r%d", name, instr.Src1)
+		case disvm.SyscallMembraneSend:
+			// instr.Src1 names the register holding the payload; Src2 is
+			// the target membrane's ID, not a register.
+			return fmt.Sprintf("%s r%d, #%d", name, instr.Src1, instr.Src2)
+		default:
+			if instr.Prime != 0 {
+				return fmt.Sprintf("%s #%d", name, instr.Prime)
+			}
+			return fmt.Sprintf("%s r%d, r%d", name, instr.Src1, instr.Src2)
+		}
+	}
+
+	name, ok := mnemonics[instr.Op]
+	if !ok {
+		return fmt.Sprintf("; unknown opcode %d", instr.Op)
+	}
+
+	switch instr.Op {
+	case disvm.OpMulPrime:
+		return fmt.Sprintf("%s r%d, r%d, #%d", name, instr.Dst, instr.Src1, instr.Prime)
+	case disvm.OpExpPrime:
+		return fmt.Sprintf("%s r%d, #%d, #%d", name, instr.Dst, instr.Prime, instr.Exp)
+	case disvm.OpFactorize:
+		return fmt.Sprintf("%s r%d, r%d", name, instr.Dst, instr.Src1)
+	case disvm.OpLoad, disvm.OpStore:
+		return fmt.Sprintf("%s r%d, r%d", name, instr.Dst, instr.Src1)
+	case disvm.OpAlloc:
+		return fmt.Sprintf("%s r%d, #%d", name, instr.Dst, instr.Src1)
+	case disvm.OpCall, disvm.OpJump:
+		return fmt.Sprintf("%s #%d", name, instr.Dst)
+	case disvm.OpBranch:
+		return fmt.Sprintf("%s r%d, #%d", name, instr.Src1, instr.Dst)
+	case disvm.OpRet:
+		return name
+	default:
+		return name
+	}
+}
+
+// Assemble parses src, one mnemonic instruction per line (blank lines and
+// lines starting with ";" are ignored), into a Dis VM instruction stream.
+func Assemble(src string) ([]disvm.Instruction, error) {
+	var code []disvm.Instruction
+	for lineNo, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		instr, err := assembleLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("disasm: line %d: %w", lineNo+1, err)
+		}
+		code = append(code, instr)
+	}
+	return code, nil
+}
+
+func assembleLine(line string) (disvm.Instruction, error) {
+	mnemonic, rest, _ := strings.Cut(line, " ")
+	operands := splitOperands(rest)
+
+	if id, ok := mnemonicToSyscall[mnemonic]; ok {
+		instr := disvm.Instruction{Op: disvm.OpSyscall, Dst: id}
+		switch id {
+		case disvm.SyscallMembraneCreate:
+			if len(operands) != 1 {
+				return disvm.Instruction{}, fmt.Errorf("%s expects 1 operand, got %d", mnemonic, len(operands))
+			}
+			imm, ok := immediate(operands[0])
+			if !ok {
+				return disvm.Instruction{}, fmt.Errorf("malformed operand for %s", mnemonic)
+			}
+			instr.Prime = imm
+
+		case disvm.SyscallMembraneEvolve:
+			if len(operands) != 1 {
+				return disvm.Instruction{}, fmt.Errorf("%s expects 1 operand, got %d", mnemonic, len(operands))
+			}
+			reg, ok := register(operands[0])
+			if !ok {
+				return disvm.Instruction{}, fmt.Errorf("malformed operand for %s", mnemonic)
+			}
+			instr.Src1 = reg
+
+		case disvm.SyscallMembraneSend:
+			if len(operands) != 2 {
+				return disvm.Instruction{}, fmt.Errorf("%s expects 2 operands, got %d", mnemonic, len(operands))
+			}
+			reg, ok1 := register(operands[0])
+			target, ok2 := immediate(operands[1])
+			if !ok1 || !ok2 {
+				return disvm.Instruction{}, fmt.Errorf("malformed operands for %s", mnemonic)
+			}
+			instr.Src1 = reg
+			instr.Src2 = uint32(target)
+
+		default:
+			// node.create/edge.create/graph.traverse have no dedicated
+			// assembler support yet; fall back to the generic
+			// immediate-or-register-pair form.
+			if len(operands) > 0 {
+				if imm, ok := immediate(operands[0]); ok {
+					instr.Prime = imm
+				} else if reg, ok := register(operands[0]); ok && len(operands) > 1 {
+					instr.Src1 = reg
+					if reg2, ok := register(operands[1]); ok {
+						instr.Src2 = reg2
+					}
+				}
+			}
+		}
+		return instr, nil
+	}
+
+	op, ok := mnemonicToOp[mnemonic]
+	if !ok {
+		return disvm.Instruction{}, fmt.Errorf("unknown mnemonic %q", mnemonic)
+	}
+
+	switch op {
+	case disvm.OpMulPrime:
+		dst, src1, prime, err := parseDstSrcImm(operands)
+		return disvm.Instruction{Op: op, Dst: dst, Src1: src1, Prime: prime}, err
+
+	case disvm.OpExpPrime:
+		if len(operands) != 3 {
+			return disvm.Instruction{}, fmt.Errorf("%s expects 3 operands, got %d", mnemonic, len(operands))
+		}
+		dst, ok := register(operands[0])
+		prime, primeOK := immediate(operands[1])
+		exp, expOK := immediate(operands[2])
+		if !ok || !primeOK || !expOK {
+			return disvm.Instruction{}, fmt.Errorf("malformed operands for %s", mnemonic)
+		}
+		return disvm.Instruction{Op: op, Dst: dst, Prime: prime, Exp: uint32(exp)}, nil
+
+	case disvm.OpFactorize, disvm.OpLoad, disvm.OpStore:
+		if len(operands) != 2 {
+			return disvm.Instruction{}, fmt.Errorf("%s expects 2 operands, got %d", mnemonic, len(operands))
+		}
+		dst, ok1 := register(operands[0])
+		src1, ok2 := register(operands[1])
+		if !ok1 || !ok2 {
+			return disvm.Instruction{}, fmt.Errorf("malformed operands for %s", mnemonic)
+		}
+		return disvm.Instruction{Op: op, Dst: dst, Src1: src1}, nil
+
+	case disvm.OpAlloc:
+		if len(operands) != 2 {
+			return disvm.Instruction{}, fmt.Errorf("%s expects 2 operands, got %d", mnemonic, len(operands))
+		}
+		dst, ok1 := register(operands[0])
+		size, ok2 := immediate(operands[1])
+		if !ok1 || !ok2 {
+			return disvm.Instruction{}, fmt.Errorf("malformed operands for %s", mnemonic)
+		}
+		return disvm.Instruction{Op: op, Dst: dst, Src1: uint32(size)}, nil
+
+	case disvm.OpCall, disvm.OpJump:
+		if len(operands) != 1 {
+			return disvm.Instruction{}, fmt.Errorf("%s expects 1 operand, got %d", mnemonic, len(operands))
+		}
+		target, ok := immediate(operands[0])
+		if !ok {
+			return disvm.Instruction{}, fmt.Errorf("malformed operand for %s", mnemonic)
+		}
+		return disvm.Instruction{Op: op, Dst: uint32(target)}, nil
+
+	case disvm.OpBranch:
+		if len(operands) != 2 {
+			return disvm.Instruction{}, fmt.Errorf("%s expects 2 operands, got %d", mnemonic, len(operands))
+		}
+		src1, ok1 := register(operands[0])
+		target, ok2 := immediate(operands[1])
+		if !ok1 || !ok2 {
+			return disvm.Instruction{}, fmt.Errorf("malformed operands for %s", mnemonic)
+		}
+		return disvm.Instruction{Op: op, Src1: src1, Dst: uint32(target)}, nil
+
+	case disvm.OpRet:
+		return disvm.Instruction{Op: op}, nil
+
+	default:
+		return disvm.Instruction{}, fmt.Errorf("no assembler support for mnemonic %q", mnemonic)
+	}
+}
+
+func parseDstSrcImm(operands []string) (dst, src1 uint32, imm uint64, err error) {
+	if len(operands) != 3 {
+		return 0, 0, 0, fmt.Errorf("expected 3 operands, got %d", len(operands))
+	}
+	dst, ok1 := register(operands[0])
+	src1, ok2 := register(operands[1])
+	imm, ok3 := immediate(operands[2])
+	if !ok1 || !ok2 || !ok3 {
+		return 0, 0, 0, fmt.Errorf("malformed operands %v", operands)
+	}
+	return dst, src1, imm, nil
+}
+
+func splitOperands(rest string) []string {
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return nil
+	}
+	parts := strings.Split(rest, ",")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+func register(tok string) (uint32, bool) {
+	if !strings.HasPrefix(tok, "r") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(tok[1:], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(n), true
+}
+
+func immediate(tok string) (uint64, bool) {
+	if !strings.HasPrefix(tok, "#") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(tok[1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,96 @@
+package disasm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cogpy/echo.kern/kernel/disvm"
+)
+
+func TestDisassemble(t *testing.T) {
+	code := []disvm.Instruction{
+		{Op: disvm.OpMulPrime, Dst: 3, Src1: 1, Prime: 7},
+		{Op: disvm.OpExpPrime, Dst: 2, Prime: 3, Exp: 5},
+		{Op: disvm.OpSyscall, Dst: disvm.SyscallMembraneCreate, Prime: 11},
+	}
+
+	want := "mulprime r3, r1, #7\nexpprime r2, #3, #5\nmembrane.create #11\n"
+	got := Disassemble(code)
+	if got != want {
+		t.Fatalf("Disassemble() = %q, want %q", got, want)
+	}
+}
+
+func TestAssembleDisassembleRoundTrip(t *testing.T) {
+	src := strings.Join([]string{
+		"mulprime r3, r1, #7",
+		"expprime r2, #3, #5",
+		"membrane.create #11",
+	}, "\n")
+
+	code, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if len(code) != 3 {
+		t.Fatalf("len(code) = %d, want 3", len(code))
+	}
+
+	got := strings.TrimRight(Disassemble(code), "\n")
+	if got != src {
+		t.Fatalf("round trip = %q, want %q", got, src)
+	}
+}
+
+func TestAssembleUnknownMnemonic(t *testing.T) {
+	if _, err := Assemble("bogus r1, r2"); err == nil {
+		t.Fatal("expected error for unknown mnemonic")
+	}
+}
+
+func TestMembraneSyscallRoundTrip(t *testing.T) {
+	src := strings.Join([]string{
+		"membrane.create #11",
+		"membrane.evolve r5",
+		"membrane.send r1, #2",
+	}, "\n")
+
+	code, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if len(code) != 3 {
+		t.Fatalf("len(code) = %d, want 3", len(code))
+	}
+
+	if code[0].Dst != disvm.SyscallMembraneCreate || code[0].Prime != 11 {
+		t.Fatalf("membrane.create = %+v, want Dst=SyscallMembraneCreate Prime=11", code[0])
+	}
+	if code[1].Dst != disvm.SyscallMembraneEvolve || code[1].Src1 != 5 {
+		t.Fatalf("membrane.evolve = %+v, want Dst=SyscallMembraneEvolve Src1=5", code[1])
+	}
+	if code[2].Dst != disvm.SyscallMembraneSend || code[2].Src1 != 1 || code[2].Src2 != 2 {
+		t.Fatalf("membrane.send = %+v, want Dst=SyscallMembraneSend Src1=1 Src2=2", code[2])
+	}
+
+	got := strings.TrimRight(Disassemble(code), "\n")
+	if got != src {
+		t.Fatalf("round trip = %q, want %q", got, src)
+	}
+}
+
+func TestAssembleMembraneEvolveRejectsMissingOperand(t *testing.T) {
+	if _, err := Assemble("membrane.evolve"); err == nil {
+		t.Fatal("expected error for membrane.evolve with no operand")
+	}
+}
+
+func TestAssembleMembraneEvolveSingleRegisterOperand(t *testing.T) {
+	code, err := Assemble("membrane.evolve r5")
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if len(code) != 1 || code[0].Src1 != 5 {
+		t.Fatalf("code = %+v, want a single instruction with Src1=5", code)
+	}
+}
@@ -6,7 +6,9 @@
 package disvm
 
 import (
+	"context"
 	"fmt"
+	"math"
 )
 
 // OpCode represents Dis VM instruction opcodes
@@ -38,6 +40,10 @@ const (
 	OpNodeCreate
 	OpEdgeCreate
 	OpGraphTraverse
+
+	// OpSyscall invokes a registered Syscall, looked up by the ID carried
+	// in Instruction.Dst. See RegisterSyscall.
+	OpSyscall
 )
 
 // Instruction represents a Dis VM instruction
@@ -72,22 +78,137 @@ type DisVM struct {
 
 	// Associated membrane
 	MembraneID uint64
+
+	// Compute-unit budget, analogous to SBF/EVM gas. MaxCU is the budget a
+	// program started with; CULeft is decremented by Execute on every
+	// instruction, and Execute (and so Run) traps with ErrOutOfCompute,
+	// leaving CULeft at 0, once an instruction costs more than CULeft.
+	// A MaxCU of 0 disables metering.
+	MaxCU  uint64
+	CULeft uint64
+
+	// HeapSize bounds how many distinct Memory addresses OpStore may fill
+	// (0 means no bound). OpFactorize writes Memory without this check.
+	HeapSize uint32
+
+	// Context carries cancellation/deadlines through to host-level syscalls.
+	Context context.Context
+
+	// Syscalls maps a syscall ID (carried in OpSyscall's Dst) to the host
+	// function that implements it. See RegisterSyscall.
+	Syscalls map[uint32]Syscall
+
+	// Tracer receives step-by-step callbacks from Run/Execute when set.
+	// Nil by default, so tracing has no cost unless a caller opts in via
+	// SetTracer.
+	Tracer Tracer
+}
+
+// SetTracer attaches a Tracer that receives CaptureStart/CaptureState/
+// CaptureFault/CaptureEnd callbacks as the VM runs. Pass nil to disable
+// tracing.
+func (vm *DisVM) SetTracer(t Tracer) {
+	vm.Tracer = t
+}
+
+// defaultSyscallCost is charged for an OpSyscall whose ID has no entry in
+// baseOpCost, so unknown/custom syscalls are still metered.
+const defaultSyscallCost = 1_000
+
+// DefaultMaxCU is the compute-unit budget used when a caller does not
+// specify one via VMOpts.
+const DefaultMaxCU = 1_000_000
+
+// ErrOutOfCompute is returned by Execute/Run when CULeft is exhausted
+// before the program finishes, the same trap shape SBF/EVM interpreters
+// use to bound untrusted program execution.
+var ErrOutOfCompute = fmt.Errorf("disvm: out of compute units")
+
+// ErrHeapExceeded is returned by Execute when a store would grow Memory
+// past HeapSize.
+var ErrHeapExceeded = fmt.Errorf("disvm: heap size exceeded")
+
+// VMOpts configures a DisVM at construction time, mirroring the
+// MaxCU/HeapSize/Context options SBF interpreters expose to callers that
+// need to run untrusted programs under a budget.
+type VMOpts struct {
+	MaxCU    uint64
+	HeapSize uint32
+	Context  context.Context
 }
 
-// NewDisVM creates a new Dis VM instance
+// NewDisVM creates a new Dis VM instance with the default compute-unit
+// budget.
 func NewDisVM(depth uint32, membraneID uint64) *DisVM {
-	return &DisVM{
+	return NewDisVMWithOpts(depth, membraneID, VMOpts{MaxCU: DefaultMaxCU})
+}
+
+// NewDisVMWithOpts creates a new Dis VM instance with an explicit compute
+// budget, heap size, and context, for callers that run untrusted membrane
+// programs and need reproducible, metered execution.
+func NewDisVMWithOpts(depth uint32, membraneID uint64, opts VMOpts) *DisVM {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	vm := &DisVM{
 		Regs:       make(map[uint64]uint64),
 		Memory:     make(map[uint64]interface{}),
 		PC:         0,
 		CallStack:  make([]uint32, 0, 256),
 		Depth:      depth,
 		MembraneID: membraneID,
+		MaxCU:      opts.MaxCU,
+		CULeft:     opts.MaxCU,
+		HeapSize:   opts.HeapSize,
+		Context:    ctx,
+		Syscalls:   make(map[uint32]Syscall),
 	}
+	registerDefaultSyscalls(vm)
+	return vm
 }
 
 // Execute executes one instruction
 func (vm *DisVM) Execute(instr Instruction) error {
+	if err := vm.chargeAndTrace(vm.PC, instr, opCost(vm, instr)); err != nil {
+		return err
+	}
+
+	if err := vm.execute(instr); err != nil {
+		if vm.Tracer != nil {
+			vm.Tracer.CaptureFault(vm.PC, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// chargeAndTrace reports instr to the Tracer and deducts cost from
+// CULeft, tripping ErrOutOfCompute if the budget can't cover it. Execute
+// calls this for the interpreter path; CompiledProgram.Run's compiled
+// steps call it too, so a program gets the same metering and tracer
+// visibility regardless of which path runs it.
+func (vm *DisVM) chargeAndTrace(pc uint32, instr Instruction, cost uint64) error {
+	if vm.Tracer != nil {
+		vm.Tracer.CaptureState(pc, instr.Op, vm.Regs, len(vm.CallStack))
+	}
+
+	if vm.MaxCU > 0 {
+		if vm.CULeft < cost {
+			vm.CULeft = 0
+			if vm.Tracer != nil {
+				vm.Tracer.CaptureFault(pc, ErrOutOfCompute)
+			}
+			return ErrOutOfCompute
+		}
+		vm.CULeft -= cost
+	}
+	return nil
+}
+
+// execute dispatches instr without touching the tracer or compute
+// budget; Execute wraps it with those cross-cutting concerns.
+func (vm *DisVM) execute(instr Instruction) error {
 	switch instr.Op {
 	case OpLoad:
 		// Load from prime-indexed memory
@@ -96,9 +217,17 @@ func (vm *DisVM) Execute(instr Instruction) error {
 		}
 
 	case OpStore:
-		// Store to prime-indexed memory
+		// Store to prime-indexed memory, bounded by HeapSize so an
+		// untrusted program can't grow Memory without limit through
+		// stores.
+		addr := uint64(instr.Dst)
+		if vm.HeapSize > 0 {
+			if _, exists := vm.Memory[addr]; !exists && uint32(len(vm.Memory)) >= vm.HeapSize {
+				return ErrHeapExceeded
+			}
+		}
 		val := vm.Regs[uint64(instr.Src1)]
-		vm.Memory[uint64(instr.Dst)] = val
+		vm.Memory[addr] = val
 
 	case OpMulPrime:
 		// Multiply by prime (NO ADDITION!)
@@ -145,17 +274,16 @@ func (vm *DisVM) Execute(instr Instruction) error {
 			return nil
 		}
 
-	case OpMembraneCreate:
-		// Create new membrane
-		fmt.Printf("Creating membrane at prime %d\n", instr.Prime)
-
-	case OpMembraneEvolve:
-		// Evolve membrane
-		fmt.Printf("Evolving membrane %d\n", vm.MembraneID)
-
-	case OpMembraneSend:
-		// Send to membrane
-		fmt.Printf("Sending to membrane %d\n", instr.Dst)
+	case OpSyscall:
+		// Dispatch to a host function registered via RegisterSyscall,
+		// the same way SBF interpreters resolve syscalls by numeric ID.
+		fn, ok := vm.Syscalls[instr.Dst]
+		if !ok {
+			return fmt.Errorf("disvm: no syscall registered for id %d", instr.Dst)
+		}
+		if err := fn.Invoke(vm, instr); err != nil {
+			return err
+		}
 
 	default:
 		return fmt.Errorf("unknown opcode: %d", instr.Op)
@@ -167,6 +295,11 @@ func (vm *DisVM) Execute(instr Instruction) error {
 
 // Run executes the program until completion
 func (vm *DisVM) Run() error {
+	if vm.Tracer != nil {
+		vm.Tracer.CaptureStart(vm, vm.Code)
+		defer vm.Tracer.CaptureEnd()
+	}
+
 	for vm.PC < uint32(len(vm.Code)) {
 		instr := vm.Code[vm.PC]
 		if err := vm.Execute(instr); err != nil {
@@ -176,20 +309,54 @@ func (vm *DisVM) Run() error {
 	return nil
 }
 
-// primeFactor performs simple prime factorization
-func primeFactor(n uint64) []uint64 {
-	factors := make([]uint64, 0)
-	// Simple trial division
-	for i := uint64(2); i*i <= n; i++ {
-		for n%i == 0 {
-			factors = append(factors, i)
-			n /= i
+// baseOpCost holds the compute-unit price of each opcode that doesn't
+// need to look at its operands to know its cost.
+var baseOpCost = map[OpCode]uint64{
+	OpLoad:           1,
+	OpStore:          1,
+	OpAlloc:          2,
+	OpMulPrime:       1,
+	OpExpPrime:       1,
+	OpCall:           1,
+	OpRet:            1,
+	OpJump:           1,
+	OpBranch:         1,
+	OpMembraneCreate: 10_000,
+	OpMembraneEvolve: 2_000,
+	OpMembraneSend:   500,
+	OpNodeCreate:     200,
+	OpEdgeCreate:     200,
+	OpGraphTraverse:  500,
+}
+
+// opCost returns the compute-unit price of executing instr. Most opcodes
+// have a fixed cost; OpFactorize and OpExpPrime scale with their operands
+// since their wall-clock cost does too, mirroring how SBF/EVM gas tables
+// charge variable-cost opcodes more than fixed ones.
+func opCost(vm *DisVM, instr Instruction) uint64 {
+	switch instr.Op {
+	case OpFactorize:
+		// Priced as trial division over [2, sqrt(n)]; primeFactor's
+		// capped wheel and Pollard rho usually take fewer steps.
+		n := vm.Regs[uint64(instr.Src1)]
+		return uint64(math.Sqrt(float64(n))) + 1
+	case OpExpPrime:
+		return uint64(instr.Exp) + 1
+	case OpSyscall:
+		// Only IDs that fit an OpCode can match a baseOpCost entry;
+		// converting a larger ID would wrap it onto an unrelated opcode.
+		if instr.Dst <= math.MaxUint8 {
+			if cost, ok := baseOpCost[OpCode(instr.Dst)]; ok {
+				return cost
+			}
 		}
+		return defaultSyscallCost
+	default:
+		if cost, ok := baseOpCost[instr.Op]; ok {
+			return cost
+		}
+		return 1
 	}
-	if n > 1 {
-		factors = append(factors, n)
-	}
-	return factors
 }
 
 // LoadProgram loads a program into the VM
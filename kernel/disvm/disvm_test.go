@@ -0,0 +1,45 @@
+package disvm
+
+import "testing"
+
+func TestExecuteTripsErrOutOfComputeWhenBudgetExhausted(t *testing.T) {
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 2})
+	vm.LoadProgram([]Instruction{
+		{Op: OpExpPrime, Dst: 1, Prime: 2, Exp: 1}, // costs 2 CU (Exp+1)
+		{Op: OpExpPrime, Dst: 1, Prime: 2, Exp: 1}, // budget exhausted here
+	})
+
+	err := vm.Run()
+	if err != ErrOutOfCompute {
+		t.Fatalf("Run() error = %v, want ErrOutOfCompute", err)
+	}
+	if vm.CULeft != 0 {
+		t.Fatalf("CULeft = %d, want 0", vm.CULeft)
+	}
+}
+
+func TestExecuteAllowsUnmeteredRunWhenMaxCUIsZero(t *testing.T) {
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	vm.LoadProgram([]Instruction{
+		{Op: OpExpPrime, Dst: 1, Prime: 2, Exp: 64},
+	})
+	if err := vm.Run(); err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+}
+
+func TestExecuteEnforcesHeapSize(t *testing.T) {
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0, HeapSize: 1})
+	vm.Regs[0] = 42
+
+	if err := vm.Execute(Instruction{Op: OpStore, Dst: 1, Src1: 0}); err != nil {
+		t.Fatalf("first store: %v", err)
+	}
+	// Overwriting an existing address must not count against HeapSize.
+	if err := vm.Execute(Instruction{Op: OpStore, Dst: 1, Src1: 0}); err != nil {
+		t.Fatalf("re-store to existing address: %v", err)
+	}
+	if err := vm.Execute(Instruction{Op: OpStore, Dst: 2, Src1: 0}); err != ErrHeapExceeded {
+		t.Fatalf("second address store error = %v, want ErrHeapExceeded", err)
+	}
+}
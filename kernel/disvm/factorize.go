@@ -0,0 +1,260 @@
+package disvm
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// wheelIncrements is the standard 2*3*5*7 wheel (48 increments summing to
+// 210): it advances trial-division candidates past multiples of 2, 3, 5,
+// and 7, skipping roughly 77% of candidates a naive "try every integer"
+// loop would visit. 11 is stripped separately as a fixed small prime
+// before the wheel starts, so the combined effect covers 2*3*5*7*11.
+var wheelIncrements = []uint64{
+	2, 4, 2, 4, 6, 2, 6, 4, 2, 4, 6, 6, 2, 6, 4, 2,
+	6, 4, 6, 8, 4, 2, 4, 2, 4, 8, 6, 4, 6, 2, 4, 6,
+	2, 6, 6, 4, 2, 4, 6, 2, 6, 4, 2, 4, 2, 10, 2, 10,
+}
+
+// maxWheelCandidate bounds wheel trial division independent of n: for a
+// hard semiprime whose smallest factor exceeds this, trial division
+// would otherwise run all the way to sqrt(n) before ever reaching
+// Miller-Rabin/Pollard rho, which is exactly the O(sqrt(n)) cost this
+// hybrid factorizer exists to avoid.
+const maxWheelCandidate = 1_000_000
+
+// millerRabinWitnesses are sufficient to prove primality deterministically
+// for every uint64 (see Jaeschke/Sorenson's results for bases up to 3.3e24,
+// well past 2^64).
+var millerRabinWitnesses = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// pollardRhoCofactorThreshold is the cofactor size above which
+// primeFactor walks the wheel (up to maxWheelCandidate) and then hands the
+// rest to Miller-Rabin/Pollard rho; a cofactor at or below it is finished
+// by plain trial division, which is already fast at that size.
+const pollardRhoCofactorThreshold = 1_000_000_000_000 // ~1e12
+
+// primeFactor factors n into its prime factors, sorted ascending, using a
+// hybrid strategy suited to the 64-bit values the VM handles: a 2*3*5*7*11
+// wheel strips small factors, Miller-Rabin proves primality of the
+// remaining cofactor, and Brent's variant of Pollard's rho splits it when
+// it's composite.
+func primeFactor(n uint64) []uint64 {
+	if n == 0 {
+		// 0 has no prime factorization, and the stripping loop below
+		// (n%p == 0, n /= p) never terminates for n == 0 since both the
+		// modulus and the quotient stay 0 forever.
+		return nil
+	}
+
+	factors := make([]uint64, 0)
+
+	for _, p := range []uint64{2, 3, 5, 7, 11} {
+		for n%p == 0 {
+			factors = append(factors, p)
+			n /= p
+		}
+	}
+
+	// The wheel's increments are laid out from 11; 13 is 11+2, so
+	// the walk from 13 begins at the wheel's second increment.
+	candidate := uint64(13)
+	wheelIdx := 1
+	for candidate <= maxWheelCandidate && candidate*candidate <= n && n > pollardRhoCofactorThreshold {
+		for n%candidate == 0 {
+			factors = append(factors, candidate)
+			n /= candidate
+		}
+		candidate += wheelIncrements[wheelIdx]
+		wheelIdx = (wheelIdx + 1) % len(wheelIncrements)
+	}
+
+	factors = append(factors, factorRemaining(n)...)
+
+	sort.Slice(factors, func(i, j int) bool { return factors[i] < factors[j] })
+	return factors
+}
+
+// factorRemaining fully factors n: by trial division once it's small
+// enough, as n itself when Miller-Rabin proves it prime, and otherwise by
+// recursively splitting n with Pollard's rho until every piece is prime.
+func factorRemaining(n uint64) []uint64 {
+	if n <= 1 {
+		return nil
+	}
+	if n <= pollardRhoCofactorThreshold {
+		return trialDivide(n)
+	}
+	if isPrime(n) {
+		return []uint64{n}
+	}
+
+	d := pollardRhoBrent(n)
+	return append(factorRemaining(d), factorRemaining(n/d)...)
+}
+
+// trialDivide factors a cofactor small enough for plain trial division to
+// be cheap.
+func trialDivide(n uint64) []uint64 {
+	factors := make([]uint64, 0)
+	for i := uint64(2); i*i <= n; i++ {
+		for n%i == 0 {
+			factors = append(factors, i)
+			n /= i
+		}
+	}
+	if n > 1 {
+		factors = append(factors, n)
+	}
+	return factors
+}
+
+// isPrime runs deterministic Miller-Rabin over witnesses sufficient to
+// decide primality for any uint64.
+func isPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37} {
+		if n == p {
+			return true
+		}
+		if n%p == 0 {
+			return false
+		}
+	}
+
+	d := n - 1
+	r := 0
+	for d%2 == 0 {
+		d /= 2
+		r++
+	}
+
+	for _, a := range millerRabinWitnesses {
+		if a >= n {
+			continue
+		}
+		if !millerRabinRound(n, d, r, a) {
+			return false
+		}
+	}
+	return true
+}
+
+func millerRabinRound(n, d uint64, r int, a uint64) bool {
+	x := modPow(a, d, n)
+	if x == 1 || x == n-1 {
+		return true
+	}
+	for i := 0; i < r-1; i++ {
+		x = modMul(x, x, n)
+		if x == n-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// modPow computes base^exp mod m using uint128-safe modular
+// multiplication so it doesn't overflow for m close to 2^64.
+func modPow(base, exp, m uint64) uint64 {
+	result := uint64(1) % m
+	base %= m
+	for exp > 0 {
+		if exp&1 == 1 {
+			result = modMul(result, base, m)
+		}
+		base = modMul(base, base, m)
+		exp >>= 1
+	}
+	return result
+}
+
+// modMul computes (a*b) mod m without overflowing uint64, using
+// math/bits to carry the full 128-bit product.
+func modMul(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, rem := bits.Div64(hi, lo, m)
+	return rem
+}
+
+// pollardRhoBatch is how many tortoise-hare steps pollardRhoBrent takes
+// between each gcd, amortizing the (comparatively expensive) gcd cost
+// over a batch of cheap modular multiplications.
+const pollardRhoBatch = 128
+
+// pollardRhoBrent finds a nontrivial factor of composite n using Brent's
+// cycle-detection variant of Pollard's rho: the tortoise checkpoint x is
+// held fixed while the hare y takes r steps, with r doubling each round
+// (the hallmark of Brent's variant over the plain Floyd cycle detection
+// it replaces), and gcd is only computed once per pollardRhoBatch hare
+// steps to amortize its cost. It retries with the next constant c
+// (c = 1, 2, 3, ...) if a round degenerates (gcd collapses straight to n).
+func pollardRhoBrent(n uint64) uint64 {
+	if n%2 == 0 {
+		return 2
+	}
+
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 { return (modMul(x, x, n) + c) % n }
+
+		x, y, d, q, r := uint64(2), uint64(2), uint64(1), uint64(1), uint64(1)
+		var ys uint64
+
+		for d == 1 {
+			x = y
+			for i := uint64(0); i < r; i++ {
+				y = f(y)
+			}
+
+			for k := uint64(0); k < r && d == 1; {
+				batch := pollardRhoBatch
+				if remaining := r - k; remaining < uint64(batch) {
+					batch = int(remaining)
+				}
+				ys = y
+				for i := 0; i < batch; i++ {
+					y = f(y)
+					q = modMul(q, absDiff(x, y), n)
+				}
+				d = gcdUint64(q, n)
+				k += uint64(batch)
+			}
+
+			r *= 2
+		}
+
+		if d == n {
+			// The batched gcd collapsed to n; step one at a time from
+			// the last checkpoint to recover the actual factor.
+			for {
+				ys = f(ys)
+				d = gcdUint64(absDiff(x, ys), n)
+				if d > 1 {
+					break
+				}
+			}
+		}
+
+		if d != n {
+			return d
+		}
+		// This c produced a degenerate cycle (d==n even after
+		// backtracking); retry with the next c.
+	}
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func gcdUint64(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
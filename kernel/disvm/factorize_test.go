@@ -0,0 +1,74 @@
+package disvm
+
+import (
+	"testing"
+	"time"
+)
+
+func product(factors []uint64) uint64 {
+	p := uint64(1)
+	for _, f := range factors {
+		p *= f
+	}
+	return p
+}
+
+func TestPrimeFactorSmall(t *testing.T) {
+	cases := map[uint64][]uint64{
+		1:   {},
+		2:   {2},
+		17:  {17},
+		360: {2, 2, 2, 3, 3, 5},
+	}
+	for n, want := range cases {
+		got := primeFactor(n)
+		if len(got) != len(want) {
+			t.Fatalf("primeFactor(%d) = %v, want %v", n, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("primeFactor(%d) = %v, want %v", n, got, want)
+			}
+		}
+	}
+}
+
+func TestPrimeFactorZeroReturnsImmediately(t *testing.T) {
+	// n == 0 makes the small-prime stripping loop's n%p/n/=p both stay 0
+	// forever if unguarded, so this must come back well under the
+	// timeout rather than hang.
+	done := make(chan []uint64, 1)
+	go func() { done <- primeFactor(0) }()
+
+	select {
+	case got := <-done:
+		if len(got) != 0 {
+			t.Fatalf("primeFactor(0) = %v, want no factors", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("primeFactor(0) did not return, want immediate nil")
+	}
+}
+
+func TestPrimeFactorHardSemiprimeIsFast(t *testing.T) {
+	// Two ~1e9 primes; neither factor is small, so this only finishes
+	// quickly if trial division hands off to Miller-Rabin/Pollard rho
+	// instead of walking all the way to sqrt(n).
+	const p, q = 1000000007, 1000001011
+	n := uint64(p) * uint64(q)
+
+	done := make(chan []uint64, 1)
+	go func() { done <- primeFactor(n) }()
+
+	select {
+	case got := <-done:
+		if product(got) != n {
+			t.Fatalf("primeFactor(%d) = %v, product = %d, want %d", n, got, product(got), n)
+		}
+		if len(got) != 2 || got[0] != p || got[1] != q {
+			t.Fatalf("primeFactor(%d) = %v, want [%d %d]", n, got, p, q)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("primeFactor took too long on a hard semiprime")
+	}
+}
@@ -0,0 +1,89 @@
+package disvm
+
+// Syscall is a host function the VM can invoke via OpSyscall, the same
+// extension point SBF interpreters use to look up syscalls by numeric ID
+// rather than hardcoding host behavior into the interpreter loop.
+type Syscall interface {
+	Invoke(vm *DisVM, instr Instruction) error
+}
+
+// SyscallFunc adapts a plain function to the Syscall interface, mirroring
+// http.HandlerFunc.
+type SyscallFunc func(vm *DisVM, instr Instruction) error
+
+// Invoke calls fn(vm, instr).
+func (fn SyscallFunc) Invoke(vm *DisVM, instr Instruction) error {
+	return fn(vm, instr)
+}
+
+// RegisterSyscall installs fn as the handler for syscall id, overwriting
+// any previously registered handler for that ID. External packages (the
+// P-system runtime, the hypergraph store, tracing tools) use this to plug
+// membrane and hypergraph behavior into the VM without editing Execute.
+func (vm *DisVM) RegisterSyscall(id uint32, fn Syscall) {
+	if vm.Syscalls == nil {
+		vm.Syscalls = make(map[uint32]Syscall)
+	}
+	vm.Syscalls[id] = fn
+}
+
+// Well-known syscall IDs for the membrane and hypergraph operations the
+// VM ships with by default. An OpSyscall instruction carries one of these
+// in Dst. Callers are free to register additional IDs above these for
+// their own host functions.
+const (
+	SyscallMembraneCreate = uint32(OpMembraneCreate)
+	SyscallMembraneEvolve = uint32(OpMembraneEvolve)
+	SyscallMembraneSend   = uint32(OpMembraneSend)
+	SyscallNodeCreate     = uint32(OpNodeCreate)
+	SyscallEdgeCreate     = uint32(OpEdgeCreate)
+	SyscallGraphTraverse  = uint32(OpGraphTraverse)
+)
+
+// registerDefaultSyscalls installs the built-in membrane and hypergraph
+// syscalls on a freshly constructed VM. Callers that want different
+// behavior can call RegisterSyscall again to override any of these.
+func registerDefaultSyscalls(vm *DisVM) {
+	vm.RegisterSyscall(SyscallMembraneCreate, SyscallFunc(syscallMembraneCreate))
+	vm.RegisterSyscall(SyscallMembraneEvolve, SyscallFunc(syscallMembraneEvolve))
+	vm.RegisterSyscall(SyscallMembraneSend, SyscallFunc(syscallMembraneSend))
+	vm.RegisterSyscall(SyscallNodeCreate, SyscallFunc(syscallNodeCreate))
+	vm.RegisterSyscall(SyscallEdgeCreate, SyscallFunc(syscallEdgeCreate))
+	vm.RegisterSyscall(SyscallGraphTraverse, SyscallFunc(syscallGraphTraverse))
+}
+
+// These default handlers are intentionally plain stubs: Execute's Tracer
+// hook (CaptureState) already records pc/op/regs for every instruction,
+// OpSyscall included, before it dispatches here, so a production VM gets
+// diagnostics through vm.Tracer when a caller opts in via SetTracer
+// instead of the unconditional stdout prints these used to be. Callers
+// that need real membrane/hypergraph behavior register their own
+// handler with RegisterSyscall (see kernel/scheduler for membrane send
+// and evolve).
+
+func syscallMembraneCreate(vm *DisVM, instr Instruction) error {
+	return nil
+}
+
+func syscallMembraneEvolve(vm *DisVM, instr Instruction) error {
+	return nil
+}
+
+func syscallMembraneSend(vm *DisVM, instr Instruction) error {
+	// instr.Dst carries the syscall ID (SyscallMembraneSend); the target
+	// membrane ID travels in Src2 instead, with Src1 naming the register
+	// holding the payload to send.
+	return nil
+}
+
+func syscallNodeCreate(vm *DisVM, instr Instruction) error {
+	return nil
+}
+
+func syscallEdgeCreate(vm *DisVM, instr Instruction) error {
+	return nil
+}
+
+func syscallGraphTraverse(vm *DisVM, instr Instruction) error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package disvm
+
+import "testing"
+
+func TestRegisterSyscallDispatchesViaOpSyscall(t *testing.T) {
+	const customSyscallID = 9000
+	var invoked bool
+	var gotSrc1 uint32
+
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	vm.RegisterSyscall(customSyscallID, SyscallFunc(func(vm *DisVM, instr Instruction) error {
+		invoked = true
+		gotSrc1 = instr.Src1
+		return nil
+	}))
+
+	if err := vm.Execute(Instruction{Op: OpSyscall, Dst: customSyscallID, Src1: 7}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if !invoked {
+		t.Fatal("registered syscall was never invoked")
+	}
+	if gotSrc1 != 7 {
+		t.Fatalf("Src1 = %d, want 7", gotSrc1)
+	}
+}
+
+func TestOpSyscallErrorsOnUnregisteredID(t *testing.T) {
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+
+	err := vm.Execute(Instruction{Op: OpSyscall, Dst: 123456})
+	if err == nil {
+		t.Fatal("expected an error for an unregistered syscall id")
+	}
+}
+
+func TestRegisterSyscallOverridesDefault(t *testing.T) {
+	var invoked bool
+
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	vm.RegisterSyscall(SyscallMembraneSend, SyscallFunc(func(vm *DisVM, instr Instruction) error {
+		invoked = true
+		return nil
+	}))
+
+	if err := vm.Execute(Instruction{Op: OpSyscall, Dst: SyscallMembraneSend}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !invoked {
+		t.Fatal("RegisterSyscall did not override the default SyscallMembraneSend handler")
+	}
+}
@@ -0,0 +1,140 @@
+package disvm
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Tracer receives step-by-step callbacks as a DisVM runs a program,
+// mirroring the EVM Tracer/JSONLogger pattern. Run calls CaptureStart
+// once before the first instruction and CaptureEnd once after the last;
+// Execute calls CaptureState before dispatching each instruction and
+// CaptureFault if dispatch or the compute budget produced an error.
+type Tracer interface {
+	CaptureStart(vm *DisVM, code []Instruction)
+	CaptureState(pc uint32, op OpCode, regs map[uint64]uint64, depth int)
+	CaptureFault(pc uint32, err error)
+	CaptureEnd()
+}
+
+// JSONTracer writes one JSON object per step to Out, in the same spirit
+// as the EVM JSONLogger.
+type JSONTracer struct {
+	Out io.Writer
+}
+
+// NewJSONTracer returns a JSONTracer that writes to out.
+func NewJSONTracer(out io.Writer) *JSONTracer {
+	return &JSONTracer{Out: out}
+}
+
+type jsonTraceStart struct {
+	Event      string `json:"event"`
+	Depth      uint32 `json:"depth"`
+	MembraneID uint64 `json:"membraneId"`
+	CodeLen    int    `json:"codeLen"`
+}
+
+type jsonTraceStep struct {
+	Event string            `json:"event"`
+	PC    uint32            `json:"pc"`
+	Op    OpCode            `json:"op"`
+	Depth int               `json:"callDepth"`
+	Regs  map[uint64]uint64 `json:"regs"`
+}
+
+type jsonTraceFault struct {
+	Event string `json:"event"`
+	PC    uint32 `json:"pc"`
+	Error string `json:"error"`
+}
+
+func (t *JSONTracer) writeLine(v interface{}) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	t.Out.Write(line)
+	t.Out.Write([]byte("\n"))
+}
+
+// CaptureStart emits a start-of-run record.
+func (t *JSONTracer) CaptureStart(vm *DisVM, code []Instruction) {
+	t.writeLine(jsonTraceStart{
+		Event:      "start",
+		Depth:      vm.Depth,
+		MembraneID: vm.MembraneID,
+		CodeLen:    len(code),
+	})
+}
+
+// CaptureState emits one record per instruction.
+func (t *JSONTracer) CaptureState(pc uint32, op OpCode, regs map[uint64]uint64, depth int) {
+	t.writeLine(jsonTraceStep{Event: "step", PC: pc, Op: op, Depth: depth, Regs: regs})
+}
+
+// CaptureFault emits a record for a failed instruction.
+func (t *JSONTracer) CaptureFault(pc uint32, err error) {
+	t.writeLine(jsonTraceFault{Event: "fault", PC: pc, Error: err.Error()})
+}
+
+// CaptureEnd emits an end-of-run record.
+func (t *JSONTracer) CaptureEnd() {
+	t.writeLine(struct {
+		Event string `json:"event"`
+	}{Event: "end"})
+}
+
+// CallGraphTracer tracks call depth across OpCall/OpRet and records the
+// deepest point reached, for diagnosing runaway recursion in membrane
+// programs.
+type CallGraphTracer struct {
+	Calls    []CallGraphEntry
+	depth    int
+	MaxDepth int
+}
+
+// CallGraphEntry records one OpCall/OpRet transition.
+type CallGraphEntry struct {
+	PC    uint32
+	Op    OpCode
+	Depth int
+}
+
+// NewCallGraphTracer returns an empty CallGraphTracer.
+func NewCallGraphTracer() *CallGraphTracer {
+	return &CallGraphTracer{}
+}
+
+// CaptureStart resets the tracer's accumulated state for a new run.
+func (t *CallGraphTracer) CaptureStart(vm *DisVM, code []Instruction) {
+	t.Calls = t.Calls[:0]
+	t.depth = 0
+	t.MaxDepth = 0
+}
+
+// CaptureState records OpCall/OpRet transitions and tracks the deepest
+// call depth seen.
+func (t *CallGraphTracer) CaptureState(pc uint32, op OpCode, regs map[uint64]uint64, depth int) {
+	switch op {
+	case OpCall:
+		t.depth++
+	case OpRet:
+		if t.depth > 0 {
+			t.depth--
+		}
+	default:
+		return
+	}
+	if t.depth > t.MaxDepth {
+		t.MaxDepth = t.depth
+	}
+	t.Calls = append(t.Calls, CallGraphEntry{PC: pc, Op: op, Depth: t.depth})
+}
+
+// CaptureFault is a no-op; call-graph tracing only cares about control
+// flow shape.
+func (t *CallGraphTracer) CaptureFault(pc uint32, err error) {}
+
+// CaptureEnd is a no-op.
+func (t *CallGraphTracer) CaptureEnd() {}
@@ -0,0 +1,52 @@
+package disvm
+
+import "testing"
+
+func TestCallGraphTracerRecordsCallAndRetSequence(t *testing.T) {
+	// Call subroutine at PC 2, which immediately returns.
+	code := []Instruction{
+		{Op: OpCall, Dst: 2},
+		{Op: OpJump, Dst: 3},
+		{Op: OpRet},
+	}
+
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	tracer := NewCallGraphTracer()
+	vm.SetTracer(tracer)
+	vm.LoadProgram(code)
+
+	if err := vm.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(tracer.Calls) != 2 {
+		t.Fatalf("Calls = %v, want 2 entries (OpCall, OpRet)", tracer.Calls)
+	}
+	if tracer.Calls[0].Op != OpCall || tracer.Calls[0].Depth != 1 {
+		t.Fatalf("Calls[0] = %+v, want OpCall at depth 1", tracer.Calls[0])
+	}
+	if tracer.Calls[1].Op != OpRet || tracer.Calls[1].Depth != 0 {
+		t.Fatalf("Calls[1] = %+v, want OpRet at depth 0", tracer.Calls[1])
+	}
+	if tracer.MaxDepth != 1 {
+		t.Fatalf("MaxDepth = %d, want 1", tracer.MaxDepth)
+	}
+}
+
+func TestCallGraphTracerIgnoresNonCallOpcodes(t *testing.T) {
+	code := []Instruction{
+		{Op: OpExpPrime, Dst: 0, Prime: 2, Exp: 1},
+	}
+
+	vm := NewDisVMWithOpts(0, 0, VMOpts{MaxCU: 0})
+	tracer := NewCallGraphTracer()
+	vm.SetTracer(tracer)
+	vm.LoadProgram(code)
+
+	if err := vm.Run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(tracer.Calls) != 0 {
+		t.Fatalf("Calls = %v, want no entries for a non-call opcode", tracer.Calls)
+	}
+}
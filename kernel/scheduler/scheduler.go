@@ -0,0 +1,186 @@
+// Package scheduler runs a P-system of Dis VM membranes concurrently.
+//
+// Each depth in the membrane hierarchy gets a soft concurrency budget
+// sized by disvm.GetConcurrencyLevel (the OEIS A000081 count for that
+// depth): a membrane runs in its own goroutine as soon as it's spawned,
+// taking one of that depth's slots when one is free and running
+// unslotted otherwise, so a membrane blocked in OpMembraneEvolve can
+// never starve the sibling whose OpMembraneSend would unblock it.
+// OpMembraneCreate/OpMembraneSend/OpMembraneEvolve become real membrane
+// spawning and inter-membrane message passing instead of fmt.Printf
+// stubs.
+package scheduler
+
+import (
+	"sync"
+
+	"github.com/cogpy/echo.kern/kernel/disvm"
+)
+
+// MembraneMessage is one OpMembraneSend payload delivered to a
+// membrane's Inbox.
+type MembraneMessage struct {
+	From    uint64
+	Payload uint64
+}
+
+// Membrane pairs a DisVM with the scheduling metadata the Scheduler needs
+// to run it: its depth (for concurrency limits and barriers) and an
+// inbox for OpMembraneSend traffic.
+type Membrane struct {
+	ID    uint64
+	Depth uint32
+	VM    *disvm.DisVM
+	Inbox chan MembraneMessage
+}
+
+// Scheduler runs a P-system of membranes concurrently. Each depth gets a
+// soft concurrency budget (a buffered channel sized by
+// disvm.GetConcurrencyLevel(depth)) that a membrane's goroutine takes a
+// slot from if one is free, and exposes a Barrier to synchronize
+// rewrite steps across a depth.
+type Scheduler struct {
+	mu        sync.Mutex
+	membranes map[uint64]*Membrane
+	slots     map[uint32]chan struct{}
+	barriers  map[uint32]*sync.WaitGroup
+	wg        sync.WaitGroup
+}
+
+// NewScheduler returns an empty Scheduler ready to Spawn membranes onto.
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		membranes: make(map[uint64]*Membrane),
+		slots:     make(map[uint32]chan struct{}),
+		barriers:  make(map[uint32]*sync.WaitGroup),
+	}
+}
+
+// Spawn creates a membrane running code at depth, wires its
+// OpMembraneCreate/OpMembraneSend/OpMembraneEvolve syscalls to the
+// scheduler, and starts it running in its own goroutine immediately.
+// The goroutine takes one of depth's disvm.GetConcurrencyLevel(depth)
+// slots if one is free (at least one slot always exists, so depth 0 and
+// anything past the table still runs) and runs without one otherwise —
+// it never blocks waiting for a slot, since a depth whose sibling count
+// outgrows its concurrency level would otherwise be able to deadlock a
+// membrane blocked in OpMembraneEvolve against the very sibling whose
+// OpMembraneSend would free a slot for it.
+func (s *Scheduler) Spawn(depth uint32, membraneID uint64, code []disvm.Instruction) *Membrane {
+	vm := disvm.NewDisVM(depth, membraneID)
+	vm.LoadProgram(code)
+
+	m := &Membrane{ID: membraneID, Depth: depth, VM: vm, Inbox: make(chan MembraneMessage, 64)}
+	s.registerMembraneSyscalls(m)
+
+	s.mu.Lock()
+	s.membranes[membraneID] = m
+	slots := s.slotsLocked(depth)
+	barrier := s.barrierLocked(depth)
+	s.mu.Unlock()
+
+	barrier.Add(1)
+	s.wg.Add(1)
+	go s.run(m, slots, barrier)
+	return m
+}
+
+// slotsLocked returns depth's concurrency-slot channel, creating it the
+// first time the depth is seen. Callers must hold s.mu.
+func (s *Scheduler) slotsLocked(depth uint32) chan struct{} {
+	if slots, ok := s.slots[depth]; ok {
+		return slots
+	}
+
+	n := int(disvm.GetConcurrencyLevel(depth))
+	if n == 0 {
+		n = 1
+	}
+	slots := make(chan struct{}, n)
+	s.slots[depth] = slots
+	return slots
+}
+
+// barrierLocked returns depth's WaitGroup, creating it if this is the
+// first membrane seen at that depth. Callers must hold s.mu.
+func (s *Scheduler) barrierLocked(depth uint32) *sync.WaitGroup {
+	b, ok := s.barriers[depth]
+	if !ok {
+		b = &sync.WaitGroup{}
+		s.barriers[depth] = b
+	}
+	return b
+}
+
+// run executes one rewrite step (VM.Run) for m, taking a slot from slots
+// if one is immediately available and releasing it on return; if every
+// slot is taken it runs unslotted rather than wait, since the slot
+// holders may themselves be blocked in OpMembraneEvolve waiting on m.
+func (s *Scheduler) run(m *Membrane, slots chan struct{}, barrier *sync.WaitGroup) {
+	select {
+	case slots <- struct{}{}:
+		defer func() { <-slots }()
+	default:
+	}
+
+	m.VM.Run()
+
+	barrier.Done()
+	s.wg.Done()
+}
+
+// registerMembraneSyscalls wires m's VM so OpMembraneCreate spawns a
+// child membrane onto the scheduler, OpMembraneSend delivers to the
+// target membrane's Inbox, and OpMembraneEvolve blocks m's own goroutine
+// until a message arrives on m's Inbox — which run's slot handling makes
+// safe even when every concurrency slot at m's depth is taken, turning
+// the previously stubbed membrane opcodes into real scheduler operations.
+func (s *Scheduler) registerMembraneSyscalls(m *Membrane) {
+	m.VM.RegisterSyscall(disvm.SyscallMembraneCreate, disvm.SyscallFunc(func(vm *disvm.DisVM, instr disvm.Instruction) error {
+		// instr.Dst is the syscall ID; instr.Prime names the child
+		// membrane's ID, the same prime-indexed addressing the rest of
+		// the VM uses. The child is spawned one depth below its parent
+		// with an empty program and is immediately running in its own
+		// goroutine and registered in s.membranes, reachable by a later
+		// OpMembraneSend the way a membrane created by host Go code is.
+		s.Spawn(m.Depth+1, instr.Prime, nil)
+		return nil
+	}))
+
+	m.VM.RegisterSyscall(disvm.SyscallMembraneSend, disvm.SyscallFunc(func(vm *disvm.DisVM, instr disvm.Instruction) error {
+		// instr.Dst is the syscall ID; instr.Src2 names the target
+		// membrane and instr.Src1 the register holding the payload.
+		s.mu.Lock()
+		target, ok := s.membranes[uint64(instr.Src2)]
+		s.mu.Unlock()
+		if !ok {
+			return nil
+		}
+		target.Inbox <- MembraneMessage{From: m.ID, Payload: vm.Regs[uint64(instr.Src1)]}
+		return nil
+	}))
+
+	m.VM.RegisterSyscall(disvm.SyscallMembraneEvolve, disvm.SyscallFunc(func(vm *disvm.DisVM, instr disvm.Instruction) error {
+		// instr.Dst is the syscall ID; instr.Src1 names the register to
+		// receive the next inbound message's payload.
+		msg := <-m.Inbox
+		vm.Regs[uint64(instr.Src1)] = msg.Payload
+		return nil
+	}))
+}
+
+// Barrier blocks until every membrane spawned at depth has completed its
+// current rewrite step, synchronizing sibling membranes before the
+// caller advances the P-system to the next step.
+func (s *Scheduler) Barrier(depth uint32) {
+	s.mu.Lock()
+	b := s.barrierLocked(depth)
+	s.mu.Unlock()
+	b.Wait()
+}
+
+// Wait blocks until every spawned membrane, at every depth, has finished
+// running.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
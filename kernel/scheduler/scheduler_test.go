@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cogpy/echo.kern/kernel/disvm"
+)
+
+func TestSchedulerMembraneSendEvolve(t *testing.T) {
+	s := NewScheduler()
+
+	// depth 3 has concurrency level 2 (disvm.GetConcurrencyLevel(3)), so
+	// sender and receiver each get their own slot here; see
+	// TestSchedulerMembraneSendEvolveExceedsConcurrencyLevel below for the
+	// case where the slot count is too small for both to fit.
+	const depth = 3
+
+	// Membrane 2 evolves, blocking until a message lands in r0.
+	receiver := s.Spawn(depth, 2, []disvm.Instruction{
+		{Op: disvm.OpSyscall, Dst: disvm.SyscallMembraneEvolve, Src1: 0},
+	})
+
+	// Membrane 1 computes 3^2 into r1 and sends it to membrane 2.
+	s.Spawn(depth, 1, []disvm.Instruction{
+		{Op: disvm.OpExpPrime, Dst: 1, Prime: 3, Exp: 2},
+		{Op: disvm.OpSyscall, Dst: disvm.SyscallMembraneSend, Src1: 1, Src2: 2},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for scheduler to finish")
+	}
+
+	if got := receiver.VM.Regs[0]; got != 9 {
+		t.Fatalf("receiver.VM.Regs[0] = %d, want 9", got)
+	}
+}
+
+func TestSchedulerMembraneSendEvolveExceedsConcurrencyLevel(t *testing.T) {
+	s := NewScheduler()
+
+	// depth 1 has concurrency level 1 (disvm.GetConcurrencyLevel(1)), one
+	// slot for two mutually-communicating membranes. If the receiver's
+	// OpMembraneEvolve held that slot while blocked, the sender could
+	// never run to unblock it; run must take the sender unslotted instead
+	// of deadlocking.
+	const depth = 1
+
+	receiver := s.Spawn(depth, 2, []disvm.Instruction{
+		{Op: disvm.OpSyscall, Dst: disvm.SyscallMembraneEvolve, Src1: 0},
+	})
+
+	s.Spawn(depth, 1, []disvm.Instruction{
+		{Op: disvm.OpExpPrime, Dst: 1, Prime: 3, Exp: 2},
+		{Op: disvm.OpSyscall, Dst: disvm.SyscallMembraneSend, Src1: 1, Src2: 2},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for scheduler to finish: sender and receiver deadlocked at a depth with only one concurrency slot")
+	}
+
+	if got := receiver.VM.Regs[0]; got != 9 {
+		t.Fatalf("receiver.VM.Regs[0] = %d, want 9", got)
+	}
+}
+
+func TestSchedulerMembraneCreateSpawnsChild(t *testing.T) {
+	s := NewScheduler()
+
+	const depth = 0
+	const childID = 13
+
+	parent := s.Spawn(depth, 1, []disvm.Instruction{
+		{Op: disvm.OpSyscall, Dst: disvm.SyscallMembraneCreate, Prime: childID},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for scheduler to finish")
+	}
+
+	s.mu.Lock()
+	child, ok := s.membranes[childID]
+	s.mu.Unlock()
+	if !ok {
+		t.Fatal("OpMembraneCreate did not register a child membrane with the scheduler")
+	}
+	if child.Depth != parent.Depth+1 {
+		t.Fatalf("child.Depth = %d, want %d", child.Depth, parent.Depth+1)
+	}
+}